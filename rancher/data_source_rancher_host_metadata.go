@@ -0,0 +1,148 @@
+package rancher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// defaultMetadataURL is the well-known address of the Rancher metadata
+// service as seen from inside a managed container.
+const defaultMetadataURL = "http://rancher-metadata/2016-07-29"
+
+// metadataHost mirrors the subset of the `self/host` metadata document that
+// is useful to Terraform; the service returns substantially more than this.
+type metadataHost struct {
+	AgentIP         string            `json:"agent_ip"`
+	Hostname        string            `json:"hostname"`
+	Labels          map[string]string `json:"labels"`
+	Memory          int               `json:"memory"`
+	Containers      []string          `json:"containers"`
+	PublicEndpoints []string          `json:"public_endpoints"`
+}
+
+func dataSourceRancherHostMetadata() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceRancherHostMetadataRead,
+
+		Schema: map[string]*schema.Schema{
+			"metadata_url": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  defaultMetadataURL,
+			},
+			"wait": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"poll_interval": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  60,
+			},
+			"agent_ip": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"hostname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"labels": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+			"memory": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"containers": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"public_endpoints": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceRancherHostMetadataRead(d *schema.ResourceData, meta interface{}) error {
+	metadataURL := d.Get("metadata_url").(string)
+	wait := d.Get("wait").(bool)
+	pollInterval := d.Get("poll_interval").(int)
+
+	host, index, err := fetchHostMetadata(metadataURL, "", wait, pollInterval)
+	if err != nil {
+		return fmt.Errorf("Error reading host metadata: %s", err)
+	}
+
+	d.SetId(fmt.Sprintf("%s-%d", host.Hostname, index))
+	d.Set("agent_ip", host.AgentIP)
+	d.Set("hostname", host.Hostname)
+	d.Set("labels", host.Labels)
+	d.Set("memory", host.Memory)
+	d.Set("containers", host.Containers)
+	d.Set("public_endpoints", host.PublicEndpoints)
+
+	return nil
+}
+
+// buildMetadataEndpoint builds the `self/host` metadata service URL, adding
+// the long-poll `wait`/`value` query params when wait is true.
+func buildMetadataEndpoint(metadataURL, index string, wait bool) string {
+	endpoint := fmt.Sprintf("%s/self/host", metadataURL)
+
+	values := url.Values{}
+	values.Set("format", "json")
+	if wait {
+		values.Set("wait", "true")
+		if index != "" {
+			values.Set("value", index)
+		}
+	}
+
+	return fmt.Sprintf("%s?%s", endpoint, values.Encode())
+}
+
+// fetchHostMetadata queries the Rancher metadata service for the `self/host`
+// document. When wait is true, it long-polls with `?wait=true&value=<index>`
+// so the caller blocks until the service reports a newer version than index.
+func fetchHostMetadata(metadataURL, index string, wait bool, pollInterval int) (*metadataHost, string, error) {
+	endpoint := buildMetadataEndpoint(metadataURL, index, wait)
+
+	client := &http.Client{
+		Timeout: time.Duration(pollInterval+10) * time.Second,
+	}
+
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("metadata service returned status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var host metadataHost
+	if err := json.Unmarshal(body, &host); err != nil {
+		return nil, "", err
+	}
+
+	return &host, resp.Header.Get("X-Rancher-Metadata-Version"), nil
+}
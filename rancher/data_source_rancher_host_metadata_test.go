@@ -0,0 +1,23 @@
+package rancher
+
+import "testing"
+
+func TestBuildMetadataEndpoint(t *testing.T) {
+	cases := []struct {
+		metadataURL string
+		index       string
+		wait        bool
+		expected    string
+	}{
+		{defaultMetadataURL, "", false, "http://rancher-metadata/2016-07-29/self/host?format=json"},
+		{defaultMetadataURL, "", true, "http://rancher-metadata/2016-07-29/self/host?format=json&wait=true"},
+		{defaultMetadataURL, "42", true, "http://rancher-metadata/2016-07-29/self/host?format=json&value=42&wait=true"},
+	}
+
+	for _, c := range cases {
+		actual := buildMetadataEndpoint(c.metadataURL, c.index, c.wait)
+		if actual != c.expected {
+			t.Fatalf("buildMetadataEndpoint(%q, %q, %v) = %q, want %q", c.metadataURL, c.index, c.wait, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package rancher
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Provider returns a terraform.ResourceProvider for Rancher.
+func Provider() terraform.ResourceProvider {
+	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"api_url": {
+				Type:        schema.TypeString,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RANCHER_URL", nil),
+			},
+			"access_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RANCHER_ACCESS_KEY", nil),
+			},
+			"secret_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RANCHER_SECRET_KEY", nil),
+			},
+		},
+
+		ResourcesMap: map[string]*schema.Resource{
+			"rancher_host": resourceRancherHost(),
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"rancher_host_metadata": dataSourceRancherHostMetadata(),
+		},
+
+		ConfigureFunc: providerConfigure,
+	}
+}
+
+func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+	return NewConfig(d.Get("api_url").(string), d.Get("access_key").(string), d.Get("secret_key").(string))
+}
@@ -3,10 +3,13 @@ package rancher
 import (
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/mitchellh/mapstructure"
 	rancher "github.com/rancher/go-rancher/v2"
 )
 
@@ -19,12 +22,43 @@ var roLabels = []string{
 	"io.rancher.host.linux_kernel_version",
 }
 
+// schedulerLabelPrefix namespaces the scheduling labels Rancher manages on a
+// Host's behalf (host label affinity/anti-affinity). Terraform should only
+// ever set these through host_labels, never directly through labels.
+const schedulerLabelPrefix = "io.rancher.scheduler."
+
+// schedulerAffinityLabel/schedulerAntiAffinityLabel (hard) and their _soft
+// counterparts hold the comma separated `key=value` host label constraints
+// Rancher schedules containers against. See
+// https://rancher.com/docs/rancher/v1.6/en/rancher-compose/scheduling/
+const (
+	schedulerAffinityLabel         = schedulerLabelPrefix + "affinity:host_label"
+	schedulerAffinitySoftLabel     = schedulerLabelPrefix + "affinity:host_label_soft"
+	schedulerAntiAffinityLabel     = schedulerLabelPrefix + "affinity:host_label_ne"
+	schedulerAntiAffinitySoftLabel = schedulerLabelPrefix + "affinity:host_label_soft_ne"
+)
+
+// isReservedLabel reports whether key is managed by Rancher (roLabels) or by
+// the host_labels scheduling block (schedulerLabelPrefix), and so should not
+// be set directly through the flat labels map.
+func isReservedLabel(key string) bool {
+	for _, lbl := range roLabels {
+		if key == lbl {
+			return true
+		}
+	}
+	return strings.HasPrefix(key, schedulerLabelPrefix)
+}
+
 func resourceRancherHost() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceRancherHostCreate,
 		Read:   resourceRancherHostRead,
 		Update: resourceRancherHostUpdate,
 		Delete: resourceRancherHostDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceRancherHostImport,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"id": &schema.Schema{
@@ -51,6 +85,89 @@ func resourceRancherHost() *schema.Resource {
 				Type:     schema.TypeMap,
 				Optional: true,
 			},
+			"host_labels": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"scheduler_affinity": {
+							Type:     schema.TypeMap,
+							Optional: true,
+						},
+						"scheduler_affinity_soft": {
+							Type:     schema.TypeMap,
+							Optional: true,
+						},
+						"scheduler_anti_affinity": {
+							Type:     schema.TypeMap,
+							Optional: true,
+						},
+						"scheduler_anti_affinity_soft": {
+							Type:     schema.TypeMap,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"compute_total": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"physical_host_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"public_endpoints": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ip_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"port": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"driver": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"driver_config": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+			"machine_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"evacuate_on_delete": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"drain_timeout": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  600,
+			},
+			"force": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"delete_containers": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 		},
 	}
 }
@@ -62,6 +179,10 @@ func resourceRancherHostCreate(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
+	if driver := d.Get("driver").(string); driver != "" {
+		return resourceRancherHostCreateFromDriver(d, meta, client, driver)
+	}
+
 	hostname := d.Get("hostname").(string)
 
 	stateConf := &resource.StateChangeConf{
@@ -84,6 +205,199 @@ func resourceRancherHostCreate(d *schema.ResourceData, meta interface{}) error {
 	return resourceRancherHostUpdate(d, meta)
 }
 
+// resourceRancherHostCreateFromDriver provisions a brand new Host by driving
+// the Docker Machine provisioner behind `/v2-beta/machines` with the given
+// driver (e.g. "amazonec2", "digitalocean", "packet") and its driver_config.
+// It waits for the Machine to bootstrap and for the Host it registers to come
+// up, then hands off to the normal update/read path.
+//
+// Machine is generated by the same codegen as Host, so it is addressed the
+// same way (client.Machine.*, Resource-embedded, correlated to a Host via
+// PhysicalHostId) rather than through a bespoke client surface.
+func resourceRancherHostCreateFromDriver(d *schema.ResourceData, meta interface{}, client *rancher.RancherClient, driver string) error {
+	hostname := d.Get("hostname").(string)
+	driverConfig := d.Get("driver_config").(map[string]interface{})
+
+	machine := &rancher.Machine{
+		Name:   hostname,
+		Driver: driver,
+	}
+	if err := setMachineDriverConfig(machine, driver, driverConfig); err != nil {
+		return fmt.Errorf("Error building driver_config for Machine (%s): %s", hostname, err)
+	}
+
+	newMachine, err := client.Machine.Create(machine)
+	if err != nil {
+		return fmt.Errorf("Error creating Machine (%s) for host: %s", hostname, err)
+	}
+
+	log.Printf("[DEBUG] Waiting for machine (%s) to bootstrap", newMachine.Id)
+
+	machineStateConf := &resource.StateChangeConf{
+		Pending:    []string{"activating", "provisioning", "registering"},
+		Target:     []string{"active"},
+		Refresh:    MachineStateRefreshFunc(client, newMachine.Id),
+		Timeout:    20 * time.Minute,
+		Delay:      5 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+	if _, waitErr := machineStateConf.WaitForState(); waitErr != nil {
+		return fmt.Errorf(
+			"Error waiting for machine (%s) to bootstrap: %s", newMachine.Id, waitErr)
+	}
+
+	physicalHostIDConf := &resource.StateChangeConf{
+		Pending:    []string{""},
+		Target:     []string{"found"},
+		Refresh:    machinePhysicalHostIdRefreshFunc(client, newMachine.Id),
+		Timeout:    5 * time.Minute,
+		Delay:      1 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+	physicalHostIDRaw, waitErr := physicalHostIDConf.WaitForState()
+	if waitErr != nil {
+		return fmt.Errorf(
+			"Error waiting for machine (%s) to report a physical_host_id: %s", newMachine.Id, waitErr)
+	}
+	physicalHostID := physicalHostIDRaw.(string)
+
+	hostStateConf := &resource.StateChangeConf{
+		Pending:        []string{"active", "not found", "registering", "activating"},
+		Target:         []string{"active"},
+		Refresh:        findHostByPhysicalHostId(client, physicalHostID),
+		Timeout:        10 * time.Minute,
+		Delay:          1 * time.Second,
+		MinTimeout:     3 * time.Second,
+		NotFoundChecks: 50,
+	}
+	host, waitErr := hostStateConf.WaitForState()
+	if waitErr != nil {
+		return fmt.Errorf(
+			"Error waiting for host provisioned by machine (%s) to become active: %s", newMachine.Id, waitErr)
+	}
+
+	d.SetId(host.(rancher.Host).Id)
+	d.Set("machine_id", newMachine.Id)
+
+	return resourceRancherHostUpdate(d, meta)
+}
+
+// findMachineIdByPhysicalHostId looks up the Machine that provisioned the
+// physical host backing a Host, so it can be persisted to machine_id and
+// torn down on delete even for hosts that were imported rather than created
+// through driver/driver_config.
+func findMachineIdByPhysicalHostId(client *rancher.RancherClient, physicalHostID string) (string, error) {
+	machines, err := client.Machine.List(NewListOpts())
+	if err != nil {
+		return "", err
+	}
+
+	for _, m := range machines.Data {
+		if m.ExternalId == physicalHostID {
+			return m.Id, nil
+		}
+	}
+
+	return "", nil
+}
+
+// supportedMachineDrivers lists the docker-machine drivers go-rancher/v2
+// exposes a typed Machine config field for (Amazonec2Config, DigitaloceanConfig,
+// PacketConfig, AzureConfig). Machine has no catch-all field Rancher reads
+// driver config out of - each driver's config must land in its own typed
+// field - so a driver outside this list (e.g. vmwarevsphere) can't be
+// provisioned by this client version at all.
+var supportedMachineDrivers = []string{"amazonec2", "digitalocean", "packet", "azure"}
+
+// setMachineDriverConfig decodes driver_config into the typed Machine field
+// Rancher actually reads the driver's config from, keyed by driver.
+func setMachineDriverConfig(machine *rancher.Machine, driver string, driverConfig map[string]interface{}) error {
+	var target interface{}
+	switch driver {
+	case "amazonec2":
+		machine.Amazonec2Config = &rancher.Amazonec2Config{}
+		target = machine.Amazonec2Config
+	case "digitalocean":
+		machine.DigitaloceanConfig = &rancher.DigitaloceanConfig{}
+		target = machine.DigitaloceanConfig
+	case "packet":
+		machine.PacketConfig = &rancher.PacketConfig{}
+		target = machine.PacketConfig
+	case "azure":
+		machine.AzureConfig = &rancher.AzureConfig{}
+		target = machine.AzureConfig
+	default:
+		return fmt.Errorf(
+			"driver %q is not supported; this provider's Rancher client only has typed Machine config for: %s",
+			driver, strings.Join(supportedMachineDrivers, ", "))
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           target,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(driverConfig)
+}
+
+// machinePhysicalHostIdRefreshFunc polls a Machine until Rancher has
+// correlated it to the underlying PhysicalHost and populated ExternalId.
+// This can lag behind the Machine reaching the "active" state, and
+// findHostByPhysicalHostId must never be called with an empty id - an empty
+// physicalHostID matches any Host that also hasn't been assigned one yet,
+// silently adopting an unrelated Host.
+func machinePhysicalHostIdRefreshFunc(client *rancher.RancherClient, machineID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		machine, err := client.Machine.ById(machineID)
+		if err != nil {
+			return nil, "", err
+		}
+		if machine == nil {
+			return nil, "", fmt.Errorf("machine %s not found while waiting for physical_host_id", machineID)
+		}
+
+		if machine.ExternalId == "" {
+			return "", "", nil
+		}
+
+		return machine.ExternalId, "found", nil
+	}
+}
+
+// findHostByPhysicalHostId looks up the Host backing a given physicalHostId,
+// which is how a Host created by a Machine is correlated back to it.
+func findHostByPhysicalHostId(client *rancher.RancherClient, physicalHostID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		hosts, _ := client.Host.List(NewListOpts())
+		var host rancher.Host
+
+		for _, h := range hosts.Data {
+			if h.PhysicalHostId == physicalHostID {
+				host = h
+				return host, host.State, nil
+			}
+		}
+
+		return nil, "not found", nil
+	}
+}
+
+// MachineStateRefreshFunc returns a resource.StateRefreshFunc that is used to
+// watch a Rancher Machine as it bootstraps a new Docker Machine host.
+func MachineStateRefreshFunc(client *rancher.RancherClient, machineID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		machine, err := client.Machine.ById(machineID)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		return *machine, machine.State, nil
+	}
+}
+
 func findHost(client *rancher.RancherClient, hostname string) resource.StateRefreshFunc {
 	return func() (interface{}, string, error) {
 
@@ -101,6 +415,157 @@ func findHost(client *rancher.RancherClient, hostname string) resource.StateRefr
 	}
 }
 
+// expandHostLabels turns the host_labels scheduling block into the Rancher
+// labels it corresponds to (io.rancher.scheduler.affinity:host_label[_soft][_ne]).
+func expandHostLabels(raw []interface{}) map[string]string {
+	result := map[string]string{}
+	if len(raw) == 0 || raw[0] == nil {
+		return result
+	}
+
+	block := raw[0].(map[string]interface{})
+	if affinity, ok := block["scheduler_affinity"].(map[string]interface{}); ok && len(affinity) > 0 {
+		result[schedulerAffinityLabel] = joinLabelConstraint(affinity)
+	}
+	if affinitySoft, ok := block["scheduler_affinity_soft"].(map[string]interface{}); ok && len(affinitySoft) > 0 {
+		result[schedulerAffinitySoftLabel] = joinLabelConstraint(affinitySoft)
+	}
+	if antiAffinity, ok := block["scheduler_anti_affinity"].(map[string]interface{}); ok && len(antiAffinity) > 0 {
+		result[schedulerAntiAffinityLabel] = joinLabelConstraint(antiAffinity)
+	}
+	if antiAffinitySoft, ok := block["scheduler_anti_affinity_soft"].(map[string]interface{}); ok && len(antiAffinitySoft) > 0 {
+		result[schedulerAntiAffinitySoftLabel] = joinLabelConstraint(antiAffinitySoft)
+	}
+	return result
+}
+
+// flattenHostLabels is the inverse of expandHostLabels, used on Read to
+// surface the scheduler labels Rancher is tracking back into host_labels.
+func flattenHostLabels(labels map[string]interface{}) []interface{} {
+	affinity := parseLabelConstraint(stringLabel(labels[schedulerAffinityLabel]))
+	affinitySoft := parseLabelConstraint(stringLabel(labels[schedulerAffinitySoftLabel]))
+	antiAffinity := parseLabelConstraint(stringLabel(labels[schedulerAntiAffinityLabel]))
+	antiAffinitySoft := parseLabelConstraint(stringLabel(labels[schedulerAntiAffinitySoftLabel]))
+	if len(affinity) == 0 && len(affinitySoft) == 0 && len(antiAffinity) == 0 && len(antiAffinitySoft) == 0 {
+		return nil
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"scheduler_affinity":           affinity,
+			"scheduler_affinity_soft":      affinitySoft,
+			"scheduler_anti_affinity":      antiAffinity,
+			"scheduler_anti_affinity_soft": antiAffinitySoft,
+		},
+	}
+}
+
+func joinLabelConstraint(m map[string]interface{}) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// stringLabel safely extracts a string out of a Host's Labels map, which
+// Rancher returns as map[string]interface{}.
+func stringLabel(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+func parseLabelConstraint(value string) map[string]interface{} {
+	result := map[string]interface{}{}
+	if value == "" {
+		return result
+	}
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		result[kv[0]] = kv[1]
+	}
+	return result
+}
+
+// flattenPublicEndpoints converts a Host's PublicEndpoints into the
+// public_endpoints computed attribute.
+func flattenPublicEndpoints(endpoints []rancher.PublicEndpoint) []interface{} {
+	result := make([]interface{}, 0, len(endpoints))
+	for _, e := range endpoints {
+		result = append(result, map[string]interface{}{
+			"ip_address": e.IpAddress,
+			"port":       e.Port,
+		})
+	}
+	return result
+}
+
+// resourceRancherHostImport supports
+// `terraform import rancher_host.foo <environment_id>/<host_id>`, and also
+// `<host_id>` alone when the provider has a default environment_id configured.
+func resourceRancherHostImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(*Config)
+
+	environmentID, hostID, err := parseHostImportId(d.Id(), config.EnvironmentId)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(hostID)
+	d.Set("environment_id", environmentID)
+
+	if err := resourceRancherHostRead(d, meta); err != nil {
+		return nil, err
+	}
+
+	// Hosts imported directly (rather than created through driver/
+	// driver_config) never had machine_id set by resourceRancherHostCreateFromDriver,
+	// so backfill it here - once, at import time - rather than on every Read,
+	// so a Host with no backing Machine doesn't pay for a full Machine.List
+	// on every refresh.
+	if d.Get("machine_id").(string) == "" {
+		if physicalHostID := d.Get("physical_host_id").(string); physicalHostID != "" {
+			client, err := meta.(*Config).EnvironmentClient(environmentID)
+			if err != nil {
+				return nil, err
+			}
+
+			machineID, err := findMachineIdByPhysicalHostId(client, physicalHostID)
+			if err != nil {
+				log.Printf("[WARN] Unable to determine machine_id for host %s: %s", hostID, err)
+			} else if machineID != "" {
+				d.Set("machine_id", machineID)
+			}
+		}
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// parseHostImportId splits a `terraform import` ID into its environment_id
+// and host_id parts. It accepts `<environment_id>/<host_id>`, or a bare
+// `<host_id>` when defaultEnvironmentID is non-empty.
+func parseHostImportId(id, defaultEnvironmentID string) (environmentID, hostID string, err error) {
+	parts := strings.SplitN(id, "/", 2)
+
+	switch len(parts) {
+	case 2:
+		return parts[0], parts[1], nil
+	case 1:
+		if defaultEnvironmentID == "" {
+			return "", "", fmt.Errorf(
+				"invalid ID %q: must be of the form <environment_id>/<host_id> unless a default environment_id is configured on the provider", id)
+		}
+		return defaultEnvironmentID, parts[0], nil
+	default:
+		return "", "", fmt.Errorf("invalid ID %q: must be of the form <environment_id>/<host_id>", id)
+	}
+}
+
 func resourceRancherHostRead(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Refreshing Host: %s", d.Id())
 	client, err := meta.(*Config).EnvironmentClient(d.Get("environment_id").(string))
@@ -130,13 +595,24 @@ func resourceRancherHostRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("description", host.Description)
 	d.Set("name", host.Name)
 	d.Set("hostname", host.Hostname)
+	d.Set("compute_total", host.ComputeTotal)
+	d.Set("physical_host_id", host.PhysicalHostId)
+	d.Set("public_endpoints", flattenPublicEndpoints(host.PublicEndpoints))
+	d.Set("host_labels", flattenHostLabels(host.Labels))
 
 	labels := host.Labels
 	// Remove read-only labels
 	for _, lbl := range roLabels {
 		delete(labels, lbl)
 	}
-	d.Set("labels", host.Labels)
+	// Scheduler labels are surfaced through host_labels instead, so diff
+	// suppress them out of the flat labels map.
+	for key := range labels {
+		if strings.HasPrefix(key, schedulerLabelPrefix) {
+			delete(labels, key)
+		}
+	}
+	d.Set("labels", labels)
 
 	return nil
 }
@@ -151,16 +627,29 @@ func resourceRancherHostUpdate(d *schema.ResourceData, meta interface{}) error {
 	name := d.Get("name").(string)
 	description := d.Get("description").(string)
 
-	// Process labels: merge ro_labels into new labels
 	labels := d.Get("labels").(map[string]interface{})
+	for key := range labels {
+		if isReservedLabel(key) {
+			return fmt.Errorf(
+				"label %q is managed by Rancher; use host_labels for scheduler affinity instead of setting it directly", key)
+		}
+	}
+
 	host, err := client.Host.ById(d.Id())
 	if err != nil {
 		return err
 	}
+
+	// Process labels: merge ro_labels into new labels
 	for _, lbl := range roLabels {
 		labels[lbl] = host.Labels[lbl]
 	}
 
+	// Merge in the labels derived from the host_labels scheduling block
+	for key, value := range expandHostLabels(d.Get("host_labels").([]interface{})) {
+		labels[key] = value
+	}
+
 	data := map[string]interface{}{
 		"name":        &name,
 		"description": &description,
@@ -188,6 +677,17 @@ func resourceRancherHostDelete(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
+	if d.Get("evacuate_on_delete").(bool) {
+		if err := evacuateHost(client, host, d); err != nil {
+			return err
+		}
+
+		host, err = client.Host.ById(id)
+		if err != nil {
+			return err
+		}
+	}
+
 	if host.State != "inactive" {
 		if _, err := client.Host.ActionDeactivate(host); err != nil {
 			return fmt.Errorf("Error deactivating Host: %s", err)
@@ -232,10 +732,110 @@ func resourceRancherHostDelete(d *schema.ResourceData, meta interface{}) error {
 			"Error waiting for host (%s) to be removed: %s", id, waitErr)
 	}
 
+	if machineID := d.Get("machine_id").(string); machineID != "" {
+		if err := deleteMachine(client, machineID); err != nil {
+			return err
+		}
+	}
+
 	d.SetId("")
 	return nil
 }
 
+// evacuateHost reschedules containers off of host before it is deactivated,
+// so destroying a host mid-deployment doesn't strand containers. Evacuate is
+// a container-reschedule action, not a Host state machine transition - the
+// Host itself stays active/inactive throughout - so completion is tracked via
+// the generic Resource.Transitioning field every Rancher resource exposes for
+// in-flight async actions, not via HostStateRefreshFunc/host.State. It waits
+// up to drain_timeout seconds for that to settle.
+func evacuateHost(client *rancher.RancherClient, host *rancher.Host, d *schema.ResourceData) error {
+	log.Printf("[INFO] Evacuating Host: %s", host.Id)
+
+	if d.Get("force").(bool) || d.Get("delete_containers").(bool) {
+		log.Printf("[WARN] Host evacuate has no force/delete_containers input in this Rancher API version; ignoring")
+	}
+
+	if _, err := client.Host.ActionEvacuate(host); err != nil {
+		return fmt.Errorf("Error evacuating Host (%s): %s", host.Id, err)
+	}
+
+	log.Printf("[DEBUG] Waiting for host (%s) to finish evacuating", host.Id)
+
+	drainTimeout := time.Duration(d.Get("drain_timeout").(int)) * time.Second
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"yes"},
+		Target:     []string{"no"},
+		Refresh:    hostTransitioningRefreshFunc(client, host.Id),
+		Timeout:    drainTimeout,
+		Delay:      1 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, waitErr := stateConf.WaitForState(); waitErr != nil {
+		return fmt.Errorf(
+			"Error waiting for host (%s) to finish evacuating: %s", host.Id, waitErr)
+	}
+
+	return nil
+}
+
+// hostTransitioningRefreshFunc watches a Host's generic Transitioning field
+// ("yes"/"no"/"error"), which every Rancher resource exposes to track
+// in-flight async actions like evacuate.
+func hostTransitioningRefreshFunc(client *rancher.RancherClient, hostID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		host, err := client.Host.ById(hostID)
+		if err != nil {
+			return nil, "", err
+		}
+		if host == nil {
+			return nil, "", fmt.Errorf("host %s not found while waiting for evacuate to finish", hostID)
+		}
+		if host.Transitioning == "error" {
+			return nil, "", fmt.Errorf("evacuating host %s failed: %s", hostID, host.TransitioningMessage)
+		}
+
+		return host, host.Transitioning, nil
+	}
+}
+
+// deleteMachine tears down the Docker Machine that provisioned a Host created
+// via the driver/driver_config path, so destroying the Host doesn't leak the
+// underlying instance at the cloud provider.
+func deleteMachine(client *rancher.RancherClient, machineID string) error {
+	machine, err := client.Machine.ById(machineID)
+	if err != nil {
+		return err
+	}
+	if machine == nil {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Deleting Machine: %s", machineID)
+
+	if err := client.Machine.Delete(machine); err != nil {
+		return fmt.Errorf("Error deleting Machine (%s): %s", machineID, err)
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"active", "removing", "removed", "error"},
+		Target:     []string{"removed"},
+		Refresh:    MachineStateRefreshFunc(client, machineID),
+		Timeout:    10 * time.Minute,
+		Delay:      1 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	if _, waitErr := stateConf.WaitForState(); waitErr != nil {
+		return fmt.Errorf(
+			"Error waiting for machine (%s) to be removed: %s", machineID, waitErr)
+	}
+
+	return nil
+}
+
 // HostStateRefreshFunc returns a resource.StateRefreshFunc that is used to watch
 // a Rancher Host.
 func HostStateRefreshFunc(client *rancher.RancherClient, hostID string) resource.StateRefreshFunc {
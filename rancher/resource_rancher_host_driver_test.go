@@ -0,0 +1,114 @@
+package rancher
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	rancher "github.com/rancher/go-rancher/v2"
+)
+
+// TestSetMachineDriverConfig confirms driver_config lands in the typed
+// Machine field Rancher actually reads the driver's config from - not a
+// "<driver>Config" key under Data, which go-rancher/v2's generated Machine
+// never reads back out on the wire.
+func TestSetMachineDriverConfig(t *testing.T) {
+	machine := &rancher.Machine{}
+	driverConfig := map[string]interface{}{
+		"accessKey":    "AKIA...",
+		"secretKey":    "shh",
+		"region":       "us-east-1",
+		"instanceType": "t2.micro",
+		"monitoring":   "true",
+	}
+
+	if err := setMachineDriverConfig(machine, "amazonec2", driverConfig); err != nil {
+		t.Fatalf("setMachineDriverConfig returned error: %s", err)
+	}
+
+	if machine.Amazonec2Config == nil {
+		t.Fatalf("Amazonec2Config is nil, want it populated from driver_config")
+	}
+	if machine.Amazonec2Config.AccessKey != "AKIA..." {
+		t.Errorf("AccessKey = %q, want AKIA...", machine.Amazonec2Config.AccessKey)
+	}
+	if machine.Amazonec2Config.Region != "us-east-1" {
+		t.Errorf("Region = %q, want us-east-1", machine.Amazonec2Config.Region)
+	}
+	if machine.Amazonec2Config.InstanceType != "t2.micro" {
+		t.Errorf("InstanceType = %q, want t2.micro", machine.Amazonec2Config.InstanceType)
+	}
+	if !machine.Amazonec2Config.Monitoring {
+		t.Errorf("Monitoring = false, want true (from driver_config's string \"true\")")
+	}
+}
+
+// TestSetMachineDriverConfigUnsupportedDriver confirms a driver with no typed
+// Machine config field in this go-rancher/v2 version (e.g. vmwarevsphere)
+// fails fast instead of silently provisioning with no config.
+func TestSetMachineDriverConfigUnsupportedDriver(t *testing.T) {
+	machine := &rancher.Machine{}
+	if err := setMachineDriverConfig(machine, "vmwarevsphere", map[string]interface{}{}); err == nil {
+		t.Fatalf("setMachineDriverConfig(vmwarevsphere) returned no error, want one - this client has no typed config field for it")
+	}
+}
+
+// TestMachinePhysicalHostIdRefreshFuncWaitsForNonEmpty exercises the real
+// polling path: Rancher can report a Machine as bootstrapped before it has
+// correlated it to a PhysicalHost, so the refresh func must keep polling
+// until ExternalId is populated instead of handing back an empty id.
+func TestMachinePhysicalHostIdRefreshFuncWaitsForNonEmpty(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2-beta/machines/machine-1", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		externalId := ""
+		if calls > 2 {
+			externalId = "phys-1"
+		}
+		fmt.Fprintf(w, `{"id":"machine-1","type":"machine","state":"active","externalId":%q}`, externalId)
+	})
+
+	client := newTestRancherClient(t, mux)
+	refresh := machinePhysicalHostIdRefreshFunc(client, "machine-1")
+
+	for i := 0; i < 2; i++ {
+		result, state, err := refresh()
+		if err != nil {
+			t.Fatalf("refresh() returned error: %s", err)
+		}
+		if state != "" || result != "" {
+			t.Fatalf("refresh() = (%v, %q), want (\"\", \"\") before ExternalId is populated", result, state)
+		}
+	}
+
+	result, state, err := refresh()
+	if err != nil {
+		t.Fatalf("refresh() returned error: %s", err)
+	}
+	if state != "found" || result != "phys-1" {
+		t.Fatalf("refresh() = (%v, %q), want (\"phys-1\", \"found\")", result, state)
+	}
+}
+
+// TestFindHostByPhysicalHostIdRequiresNonEmptyId documents why
+// resourceRancherHostCreateFromDriver must never call findHostByPhysicalHostId
+// with an empty physicalHostID: an unrelated Host that also hasn't been
+// assigned a PhysicalHostId yet would match and be silently adopted.
+func TestFindHostByPhysicalHostIdRequiresNonEmptyId(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2-beta/hosts", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"host-unrelated","type":"host","state":"active","physicalHostId":""}]}`)
+	})
+
+	client := newTestRancherClient(t, mux)
+	refresh := findHostByPhysicalHostId(client, "")
+
+	result, state, err := refresh()
+	if err != nil {
+		t.Fatalf("refresh() returned error: %s", err)
+	}
+	if state == "not found" || result == nil {
+		t.Fatalf("findHostByPhysicalHostId(\"\") unexpectedly found nothing; this test documents the hazard, it should match the unrelated host")
+	}
+}
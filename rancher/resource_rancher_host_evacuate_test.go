@@ -0,0 +1,102 @@
+package rancher
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func evacuateTestResourceData(t *testing.T, drainTimeout int) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, resourceRancherHost().Schema, map[string]interface{}{
+		"drain_timeout": drainTimeout,
+	})
+}
+
+// TestEvacuateHostWaitsForTransitioningToClear drives evacuateHost against a
+// real rancher.RancherClient backed by an httptest server: the action
+// returns immediately, but the Host stays Transitioning "yes" for a couple
+// of polls before clearing, which is how Rancher actually reports evacuate
+// completion (there's no dedicated Host state for it).
+func TestEvacuateHostWaitsForTransitioningToClear(t *testing.T) {
+	actionCalled := false
+	polls := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2-beta/hosts/host-1", func(w http.ResponseWriter, r *http.Request) {
+		base := "http://" + r.Host
+		if r.Method == http.MethodPost {
+			actionCalled = true
+			fmt.Fprintf(w, `{"id":"host-1","type":"host","state":"active","transitioning":"yes","actions":{"evacuate":"%s/v2-beta/hosts/host-1"}}`, base)
+			return
+		}
+
+		if !actionCalled {
+			fmt.Fprintf(w, `{"id":"host-1","type":"host","state":"active","transitioning":"no","actions":{"evacuate":"%s/v2-beta/hosts/host-1"}}`, base)
+			return
+		}
+
+		polls++
+		transitioning := "yes"
+		if polls > 2 {
+			transitioning = "no"
+		}
+		fmt.Fprintf(w, `{"id":"host-1","type":"host","state":"active","transitioning":%q,"actions":{"evacuate":"%s/v2-beta/hosts/host-1"}}`, transitioning, base)
+	})
+
+	client := newTestRancherClient(t, mux)
+
+	host, err := client.Host.ById("host-1")
+	if err != nil {
+		t.Fatalf("client.Host.ById: %s", err)
+	}
+
+	d := evacuateTestResourceData(t, 30)
+
+	if err := evacuateHost(client, host, d); err != nil {
+		t.Fatalf("evacuateHost returned error: %s", err)
+	}
+	if !actionCalled {
+		t.Fatalf("evacuateHost never called the evacuate action")
+	}
+	if polls < 3 {
+		t.Fatalf("evacuateHost returned before Transitioning actually cleared (polled %d times)", polls)
+	}
+}
+
+// TestEvacuateHostFailsOnTransitioningError confirms evacuateHost surfaces a
+// Transitioning "error" state as an error instead of waiting for its Timeout.
+func TestEvacuateHostFailsOnTransitioningError(t *testing.T) {
+	actionCalled := false
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2-beta/hosts/host-1", func(w http.ResponseWriter, r *http.Request) {
+		base := "http://" + r.Host
+		if r.Method == http.MethodPost {
+			actionCalled = true
+			fmt.Fprintf(w, `{"id":"host-1","type":"host","state":"active","transitioning":"yes","actions":{"evacuate":"%s/v2-beta/hosts/host-1"}}`, base)
+			return
+		}
+
+		if !actionCalled {
+			fmt.Fprintf(w, `{"id":"host-1","type":"host","state":"active","transitioning":"no","actions":{"evacuate":"%s/v2-beta/hosts/host-1"}}`, base)
+			return
+		}
+
+		fmt.Fprintf(w, `{"id":"host-1","type":"host","state":"active","transitioning":"error","transitioningMessage":"container stuck","actions":{"evacuate":"%s/v2-beta/hosts/host-1"}}`, base)
+	})
+
+	client := newTestRancherClient(t, mux)
+
+	host, err := client.Host.ById("host-1")
+	if err != nil {
+		t.Fatalf("client.Host.ById: %s", err)
+	}
+
+	d := evacuateTestResourceData(t, 30)
+
+	if err := evacuateHost(client, host, d); err == nil {
+		t.Fatalf("evacuateHost returned no error, want an error for Transitioning=error")
+	}
+}
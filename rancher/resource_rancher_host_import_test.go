@@ -0,0 +1,77 @@
+package rancher
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseHostImportId(t *testing.T) {
+	cases := []struct {
+		id                string
+		defaultEnv        string
+		wantEnvironmentID string
+		wantHostID        string
+		wantErr           bool
+	}{
+		{"env1/host1", "", "env1", "host1", false},
+		{"env1/host1", "env2", "env1", "host1", false},
+		{"host1", "env2", "env2", "host1", false},
+		{"host1", "", "", "", true},
+	}
+
+	for _, c := range cases {
+		environmentID, hostID, err := parseHostImportId(c.id, c.defaultEnv)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseHostImportId(%q, %q) = nil error, want error", c.id, c.defaultEnv)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseHostImportId(%q, %q) returned unexpected error: %s", c.id, c.defaultEnv, err)
+			continue
+		}
+		if environmentID != c.wantEnvironmentID || hostID != c.wantHostID {
+			t.Errorf("parseHostImportId(%q, %q) = (%q, %q), want (%q, %q)",
+				c.id, c.defaultEnv, environmentID, hostID, c.wantEnvironmentID, c.wantHostID)
+		}
+	}
+}
+
+// TestFindMachineIdByPhysicalHostId exercises the real list-and-correlate
+// call resourceRancherHostImport makes to backfill machine_id: it lists every
+// Machine and matches ExternalId client-side, once per call.
+func TestFindMachineIdByPhysicalHostId(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2-beta/machines", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"data":[
+			{"id":"machine-1","type":"machine","state":"active","externalId":"phys-1"},
+			{"id":"machine-2","type":"machine","state":"active","externalId":"phys-2"}
+		]}`)
+	})
+
+	client := newTestRancherClient(t, mux)
+
+	machineID, err := findMachineIdByPhysicalHostId(client, "phys-2")
+	if err != nil {
+		t.Fatalf("findMachineIdByPhysicalHostId returned error: %s", err)
+	}
+	if machineID != "machine-2" {
+		t.Fatalf("findMachineIdByPhysicalHostId(phys-2) = %q, want machine-2", machineID)
+	}
+
+	machineID, err = findMachineIdByPhysicalHostId(client, "phys-unknown")
+	if err != nil {
+		t.Fatalf("findMachineIdByPhysicalHostId returned error: %s", err)
+	}
+	if machineID != "" {
+		t.Fatalf("findMachineIdByPhysicalHostId(phys-unknown) = %q, want \"\"", machineID)
+	}
+
+	if calls != 2 {
+		t.Fatalf("Machine.List called %d times, want 2 (once per findMachineIdByPhysicalHostId call)", calls)
+	}
+}
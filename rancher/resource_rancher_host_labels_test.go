@@ -0,0 +1,96 @@
+package rancher
+
+import (
+	"testing"
+
+	rancher "github.com/rancher/go-rancher/v2"
+)
+
+func TestExpandHostLabels(t *testing.T) {
+	raw := []interface{}{
+		map[string]interface{}{
+			"scheduler_affinity":           map[string]interface{}{"zone": "us-east-1"},
+			"scheduler_affinity_soft":      map[string]interface{}{"rack": "a"},
+			"scheduler_anti_affinity":      map[string]interface{}{"zone": "us-west-1"},
+			"scheduler_anti_affinity_soft": map[string]interface{}{"rack": "b"},
+		},
+	}
+
+	labels := expandHostLabels(raw)
+
+	cases := map[string]string{
+		schedulerAffinityLabel:         "zone=us-east-1",
+		schedulerAffinitySoftLabel:     "rack=a",
+		schedulerAntiAffinityLabel:     "zone=us-west-1",
+		schedulerAntiAffinitySoftLabel: "rack=b",
+	}
+	for key, expected := range cases {
+		if labels[key] != expected {
+			t.Errorf("labels[%q] = %q, want %q", key, labels[key], expected)
+		}
+	}
+}
+
+func TestExpandHostLabelsEmpty(t *testing.T) {
+	if labels := expandHostLabels(nil); len(labels) != 0 {
+		t.Fatalf("expandHostLabels(nil) = %v, want empty map", labels)
+	}
+}
+
+func TestFlattenHostLabelsRoundTrip(t *testing.T) {
+	raw := []interface{}{
+		map[string]interface{}{
+			"scheduler_affinity":           map[string]interface{}{"zone": "us-east-1"},
+			"scheduler_affinity_soft":      map[string]interface{}{"rack": "a"},
+			"scheduler_anti_affinity":      map[string]interface{}{"zone": "us-west-1"},
+			"scheduler_anti_affinity_soft": map[string]interface{}{"rack": "b"},
+		},
+	}
+
+	labels := expandHostLabels(raw)
+	asInterface := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		asInterface[k] = v
+	}
+	flattened := flattenHostLabels(asInterface)
+	if len(flattened) != 1 {
+		t.Fatalf("flattenHostLabels(...) returned %d blocks, want 1", len(flattened))
+	}
+
+	block := flattened[0].(map[string]interface{})
+	if got := block["scheduler_affinity"].(map[string]interface{})["zone"]; got != "us-east-1" {
+		t.Errorf("scheduler_affinity[zone] = %v, want us-east-1", got)
+	}
+	if got := block["scheduler_affinity_soft"].(map[string]interface{})["rack"]; got != "a" {
+		t.Errorf("scheduler_affinity_soft[rack] = %v, want a", got)
+	}
+	if got := block["scheduler_anti_affinity"].(map[string]interface{})["zone"]; got != "us-west-1" {
+		t.Errorf("scheduler_anti_affinity[zone] = %v, want us-west-1", got)
+	}
+	if got := block["scheduler_anti_affinity_soft"].(map[string]interface{})["rack"]; got != "b" {
+		t.Errorf("scheduler_anti_affinity_soft[rack] = %v, want b", got)
+	}
+}
+
+func TestFlattenHostLabelsEmpty(t *testing.T) {
+	if flattened := flattenHostLabels(map[string]interface{}{}); flattened != nil {
+		t.Fatalf("flattenHostLabels({}) = %v, want nil", flattened)
+	}
+}
+
+func TestFlattenPublicEndpoints(t *testing.T) {
+	endpoints := []rancher.PublicEndpoint{
+		{IpAddress: "10.0.0.1", Port: 80},
+		{IpAddress: "10.0.0.2", Port: 443},
+	}
+
+	flattened := flattenPublicEndpoints(endpoints)
+	if len(flattened) != 2 {
+		t.Fatalf("flattenPublicEndpoints(...) returned %d entries, want 2", len(flattened))
+	}
+
+	first := flattened[0].(map[string]interface{})
+	if first["ip_address"] != "10.0.0.1" || first["port"] != int64(80) {
+		t.Errorf("unexpected first entry: %v", first)
+	}
+}
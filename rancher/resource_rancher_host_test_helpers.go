@@ -0,0 +1,40 @@
+package rancher
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rancher "github.com/rancher/go-rancher/v2"
+)
+
+// newTestRancherClient wires up a *rancher.RancherClient backed by an
+// httptest.Server, so tests can exercise the real client (schema discovery,
+// doById/doList/doAction) instead of a hand-rolled stub. mux should already
+// have the "host"/"machine" data endpoints registered; this only adds the
+// "/v2-beta" schema-discovery endpoint those rely on.
+func newTestRancherClient(t *testing.T, mux *http.ServeMux) *rancher.RancherClient {
+	mux.HandleFunc("/v2-beta", func(w http.ResponseWriter, r *http.Request) {
+		base := "http://" + r.Host
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-API-Schemas", base+"/v2-beta")
+		fmt.Fprintf(w, `{"type":"schemas","data":[
+			{"id":"host","type":"schema","collectionMethods":["GET"],"resourceMethods":["GET"],"links":{"collection":"%[1]s/v2-beta/hosts"}},
+			{"id":"machine","type":"schema","collectionMethods":["GET"],"resourceMethods":["GET"],"links":{"collection":"%[1]s/v2-beta/machines"}}
+		]}`, base)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := rancher.NewRancherClient(&rancher.ClientOpts{
+		Url:       server.URL,
+		AccessKey: "test",
+		SecretKey: "test",
+	})
+	if err != nil {
+		t.Fatalf("NewRancherClient: %v", err)
+	}
+	return client
+}